@@ -0,0 +1,117 @@
+// Package counter implements the channel-based visit counter as a proper
+// CSP-style actor: a single goroutine owns the state, and callers talk to
+// it exclusively through typed messages over a request channel.
+package counter
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed is returned when a request is made after the Counter's actor
+// goroutine has been stopped.
+var ErrClosed = errors.New("counter: actor has been closed")
+
+type opKind int
+
+const (
+	opIncAndGet opKind = iota
+	opGet
+	opReset
+)
+
+type request struct {
+	kind  opKind
+	reply chan uint64
+}
+
+// Counter owns a single uint64 value and serializes every read and write
+// through one goroutine, instead of sharing the value via a mutex or a
+// pointer-in-channel trick. All exported methods are safe to call from
+// any number of goroutines.
+type Counter struct {
+	requests  chan request
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewCounter starts the actor goroutine and returns a ready-to-use
+// Counter. Call Close when the Counter is no longer needed to stop the
+// goroutine.
+func NewCounter() *Counter {
+	c := &Counter{
+		requests: make(chan request),
+		done:     make(chan struct{}),
+	}
+
+	go c.run()
+
+	return c
+}
+
+func (c *Counter) run() {
+	var value uint64
+
+	for {
+		select {
+		case req := <-c.requests:
+			switch req.kind {
+			case opIncAndGet:
+				value++
+			case opReset:
+				value = 0
+			case opGet:
+				// value is unchanged; fall through to reply.
+			}
+			req.reply <- value
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// send delivers req to the actor goroutine, honoring ctx cancellation and
+// the Counter being closed while the request is in flight.
+func (c *Counter) send(ctx context.Context, req request) (uint64, error) {
+	select {
+	case c.requests <- req:
+	case <-c.done:
+		return 0, ErrClosed
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+
+	select {
+	case v := <-req.reply:
+		return v, nil
+	case <-c.done:
+		return 0, ErrClosed
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// IncAndGet increments the counter and returns the new value. It honors
+// ctx cancellation instead of blocking forever if the actor goroutine has
+// stopped responding.
+func (c *Counter) IncAndGet(ctx context.Context) (uint64, error) {
+	return c.send(ctx, request{kind: opIncAndGet, reply: make(chan uint64, 1)})
+}
+
+// Get returns the current value without modifying it.
+func (c *Counter) Get(ctx context.Context) (uint64, error) {
+	return c.send(ctx, request{kind: opGet, reply: make(chan uint64, 1)})
+}
+
+// Reset sets the counter back to zero and returns the new value.
+func (c *Counter) Reset(ctx context.Context) (uint64, error) {
+	return c.send(ctx, request{kind: opReset, reply: make(chan uint64, 1)})
+}
+
+// Close stops the actor goroutine. It is safe to call more than once,
+// including concurrently.
+func (c *Counter) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return nil
+}
@@ -0,0 +1,198 @@
+// Package hub fans out visit-count updates to WebSocket subscribers,
+// exercising the register/unregister/broadcast actor pattern on a
+// realistic fan-out workload instead of a single counter.
+package hub
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = (pongWait * 9) / 10
+
+	// sendBufferSize bounds how far a subscriber's write goroutine may
+	// fall behind before it's treated as a slow consumer and dropped.
+	sendBufferSize = 16
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// Event is broadcast to every subscriber whenever a visit handler
+// increments its counter.
+type Event struct {
+	Strategy string `json:"strategy"`
+	Count    uint64 `json:"count"`
+}
+
+type client struct {
+	send chan Event
+}
+
+// Hub fans out Events to every connected WebSocket subscriber over a
+// register/unregister/broadcast channel protocol, so subscriber
+// bookkeeping lives on a single goroutine instead of behind a mutex.
+// Construct one with New.
+type Hub struct {
+	register   chan *client
+	unregister chan *client
+	broadcast  chan Event
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// New starts the hub's run loop and returns a ready-to-use Hub.
+func New() *Hub {
+	h := &Hub{
+		register:   make(chan *client),
+		unregister: make(chan *client),
+		broadcast:  make(chan Event),
+		done:       make(chan struct{}),
+	}
+
+	go h.run()
+
+	return h
+}
+
+func (h *Hub) run() {
+	clients := make(map[*client]struct{})
+
+	for {
+		select {
+		case c := <-h.register:
+			clients[c] = struct{}{}
+
+		case c := <-h.unregister:
+			if _, ok := clients[c]; ok {
+				delete(clients, c)
+				close(c.send)
+			}
+
+		case event := <-h.broadcast:
+			for c := range clients {
+				select {
+				case c.send <- event:
+				default:
+					// Slow consumer: drop it instead of letting one
+					// laggard connection block every other broadcast.
+					delete(clients, c)
+					close(c.send)
+				}
+			}
+
+		case <-h.done:
+			for c := range clients {
+				close(c.send)
+			}
+			return
+		}
+	}
+}
+
+// Publish broadcasts event to every connected subscriber. A nil *Hub is a
+// valid no-op receiver, so callers that don't need fan-out (e.g. a
+// benchmark isolating a single synchronization strategy) can pass nil
+// instead of standing up a Hub just to discard its events.
+func (h *Hub) Publish(event Event) {
+	if h == nil {
+		return
+	}
+
+	select {
+	case h.broadcast <- event:
+	case <-h.done:
+	}
+}
+
+// Close stops the hub's run loop and disconnects every subscriber. It is
+// safe to call more than once, including concurrently.
+func (h *Hub) Close() error {
+	h.closeOnce.Do(func() { close(h.done) })
+	return nil
+}
+
+// ServeWS upgrades r to a WebSocket connection and streams Events to it
+// until the client disconnects or the hub is closed.
+func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("hub: falha ao atualizar conexão para websocket: %v", err)
+		return
+	}
+
+	c := &client{send: make(chan Event, sendBufferSize)}
+
+	select {
+	case h.register <- c:
+	case <-h.done:
+		conn.Close()
+		return
+	}
+
+	go h.writePump(conn, c)
+	go h.readPump(conn, c)
+}
+
+// readPump's only job is to notice the connection has died (a read error
+// or close frame) and process pong keepalives; the protocol itself is
+// server to client only.
+func (h *Hub) readPump(conn *websocket.Conn, c *client) {
+	defer func() {
+		select {
+		case h.unregister <- c:
+		case <-h.done:
+		}
+		conn.Close()
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+func (h *Hub) writePump(conn *websocket.Conn, c *client) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case event, ok := <-c.send:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package hub
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestHubBroadcastsToAllSubscribers(t *testing.T) {
+	h := New()
+	defer h.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(h.ServeWS))
+	defer ts.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	const numSubscribers = 3
+	const numEvents = 5
+
+	conns := make([]*websocket.Conn, numSubscribers)
+	for i := range conns {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("erro ao conectar subscriber %d: %v", i, err)
+		}
+		defer conn.Close()
+		conns[i] = conn
+	}
+
+	// Registration happens asynchronously on the hub's run loop, so give
+	// it a moment to catch up before publishing.
+	time.Sleep(50 * time.Millisecond)
+
+	for i := 1; i <= numEvents; i++ {
+		h.Publish(Event{Strategy: "mutex", Count: uint64(i)})
+	}
+
+	for i, conn := range conns {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for j := 0; j < numEvents; j++ {
+			var event Event
+			if err := conn.ReadJSON(&event); err != nil {
+				t.Fatalf("subscriber %d: erro ao ler evento %d: %v", i, j, err)
+			}
+			if event.Count != uint64(j+1) {
+				t.Errorf("subscriber %d: esperado count %d, obtido %d", i, j+1, event.Count)
+			}
+		}
+	}
+}
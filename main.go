@@ -1,79 +1,233 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/rafa-souza-dev/synchro/counter"
+	"github.com/rafa-souza-dev/synchro/hub"
+	"github.com/rafa-souza-dev/synchro/metrics"
+	"github.com/rafa-souza-dev/synchro/shard"
+	"github.com/rafa-souza-dev/synchro/storage"
 )
 
+const defaultShutdownGracePeriod = 15 * time.Second
+
 func main() {
-	var visits uint64
-	mux := chi.NewMux()
-	ch := make(chan *uint64, 1)
-	defer close(ch)
-	ch <- &visits
-	var mu sync.Mutex
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	mux.Use(middleware.RequestID)
-	mux.Use(middleware.RealIP)
-	mux.Use(middleware.Logger)
-	mux.Use(middleware.Recoverer)
+	if err := run(ctx, ":8080", shutdownGracePeriod()); err != nil {
+		log.Fatal(err)
+	}
+}
 
-	mux.Get("/health-check", healthCheck)
-	mux.Get("/visit-with-mutex", visitWithMutex(&visits, &mu))
-	mux.Get("/visit-with-atomic", visitWithAtomic(&visits))
-	mux.Get("/visit-with-channel", visitWithChannel(ch))
+// shutdownGracePeriod reads SHUTDOWN_GRACE_PERIOD, a duration string
+// understood by time.ParseDuration (e.g. "30s"), so operators can tune
+// how long in-flight requests get to finish before a forced shutdown.
+func shutdownGracePeriod() time.Duration {
+	if v := os.Getenv("SHUTDOWN_GRACE_PERIOD"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+
+	return defaultShutdownGracePeriod
+}
+
+// run builds the server, starts it in the background, and blocks until
+// ctx is canceled (typically by a signal), at which point it drains
+// in-flight requests with a graceful shutdown bounded by gracePeriod.
+func run(ctx context.Context, addr string, gracePeriod time.Duration) error {
+	mux, cleanup, err := newMux("visits.wal", prometheus.NewRegistry())
+	if err != nil {
+		return err
+	}
+	defer cleanup()
 
 	s := &http.Server{
-		Addr:           ":8080",
+		Addr:           addr,
 		Handler:        mux,
 		ReadTimeout:    10 * time.Second,
 		WriteTimeout:   10 * time.Second,
 		MaxHeaderBytes: 1 << 20,
 	}
 
-	log.Fatal(s.ListenAndServe())	
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	return s.Shutdown(shutdownCtx)
+}
+
+// newMux wires up every route and its dependencies, returning the handler
+// plus a cleanup func that releases the counter actor and storage
+// backend. Callers must invoke cleanup once the server has stopped
+// serving, whether that's main (on process exit) or a test (once its
+// httptest.Server is closed).
+func newMux(storePath string, reg *prometheus.Registry) (http.Handler, func(), error) {
+	var visits uint64
+	var mu sync.Mutex
+	ctr := counter.NewCounter()
+
+	rec := metrics.NewRecorder(reg)
+
+	store, err := storage.NewFileStore(storePath)
+	if err != nil {
+		ctr.Close()
+		return nil, nil, err
+	}
+
+	h := hub.New()
+
+	cleanup := func() {
+		ctr.Close()
+		store.Close()
+		h.Close()
+	}
+
+	mux := chi.NewMux()
+	mux.Use(middleware.RequestID)
+	mux.Use(middleware.RealIP)
+	mux.Use(middleware.Logger)
+	mux.Use(middleware.Recoverer)
+	mux.Use(rec.Middleware)
+
+	mux.Get("/health-check", healthCheck)
+	mux.Get("/metrics", rec.Handler().ServeHTTP)
+	mux.Get("/visits/stream", h.ServeWS)
+	mux.Get("/visit-with-mutex", visitWithMutex(&visits, &mu, rec, h))
+	mux.Get("/visit-with-atomic", visitWithAtomic(&visits, rec, h))
+	mux.Get("/visit-with-channel", visitWithChannel(ctr, rec, h))
+	mux.Get("/visit-with-store", visitWithStore(store, rec))
+	mux.Get("/visit-with-shards", visitWithShards(shard.NewCounter(), rec))
+	mux.Get("/visit-with-singleflight", visitWithSingleflight(store, rec))
+
+	return mux, cleanup, nil
 }
 
 func healthCheck(w http.ResponseWriter, r *http.Request) {
 	io.WriteString(w, "Hello, world!\n")
 }
 
-func visitWithMutex(visits *uint64, mu *sync.Mutex) http.HandlerFunc {
+func visitWithMutex(visits *uint64, mu *sync.Mutex, rec *metrics.Recorder, h *hub.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
 		mu.Lock()
 		*visits++
 		current := *visits
 		mu.Unlock()
+		rec.ObserveContention(metrics.StrategyMutex, time.Since(start))
+		h.Publish(hub.Event{Strategy: metrics.StrategyMutex, Count: current})
 
 		message := fmt.Sprintf("Olá! Você teve %d visitas.", current)
-		
+
 		io.WriteString(w, message)
 	}
 }
 
-func visitWithAtomic(visits *uint64) http.HandlerFunc {
+func visitWithAtomic(visits *uint64, rec *metrics.Recorder, h *hub.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
 		current := atomic.AddUint64(visits, 1)
+		rec.ObserveContention(metrics.StrategyAtomic, time.Since(start))
+		h.Publish(hub.Event{Strategy: metrics.StrategyAtomic, Count: current})
+
 		message := fmt.Sprintf("Olá! Você teve %d visitas.", current)
 
 		io.WriteString(w, message)
 	}
 }
 
-func visitWithChannel(ch chan *uint64) http.HandlerFunc {
+func visitWithChannel(ctr *counter.Counter, rec *metrics.Recorder, h *hub.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		visits := <- ch
-		*visits++
-		message := fmt.Sprintf("Olá! Você teve %d visitas.", *visits)
-		ch <- visits
+		start := time.Now()
+		current, err := ctr.IncAndGet(r.Context())
+		rec.ObserveContention(metrics.StrategyChannel, time.Since(start))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.Publish(hub.Event{Strategy: metrics.StrategyChannel, Count: current})
+
+		message := fmt.Sprintf("Olá! Você teve %d visitas.", current)
+
+		io.WriteString(w, message)
+	}
+}
+
+func visitWithStore(store storage.CounterStore, rec *metrics.Recorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		current, err := store.Increment(r.Context())
+		rec.ObserveContention(metrics.StrategyStore, time.Since(start))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		message := fmt.Sprintf("Olá! Você teve %d visitas.", current)
+
+		io.WriteString(w, message)
+	}
+}
+
+func visitWithShards(sc *shard.Counter, rec *metrics.Recorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		current := sc.IncAndGet()
+		rec.ObserveContention(metrics.StrategyShards, time.Since(start))
+
+		message := fmt.Sprintf("Olá! Você teve %d visitas.", current)
+
+		io.WriteString(w, message)
+	}
+}
+
+// visitWithSingleflight coalesces concurrent callers into a single
+// Increment on store: while one request is in flight, any other request
+// that arrives joins it and receives the same result instead of issuing
+// its own fsync/round-trip. That cuts load on a slow store at the cost of
+// concurrent callers no longer each getting their own increment.
+func visitWithSingleflight(store storage.CounterStore, rec *metrics.Recorder) http.HandlerFunc {
+	var g singleflight.Group
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		v, err, _ := g.Do("increment", func() (interface{}, error) {
+			return store.Increment(r.Context())
+		})
+		rec.ObserveContention(metrics.StrategySingleflight, time.Since(start))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		message := fmt.Sprintf("Olá! Você teve %d visitas.", v.(uint64))
 
 		io.WriteString(w, message)
 	}
@@ -0,0 +1,115 @@
+// Package metrics provides a small Prometheus-backed observability layer
+// used to compare the mutex, atomic and channel visit strategies under
+// real HTTP load.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Strategy labels used to partition the collectors below. Keep these in
+// sync with the route table in main.go.
+const (
+	StrategyMutex        = "mutex"
+	StrategyAtomic       = "atomic"
+	StrategyChannel      = "channel"
+	StrategyStore        = "store"
+	StrategyShards       = "shards"
+	StrategySingleflight = "singleflight"
+)
+
+var routeStrategy = map[string]string{
+	"/visit-with-mutex":        StrategyMutex,
+	"/visit-with-atomic":       StrategyAtomic,
+	"/visit-with-channel":      StrategyChannel,
+	"/visit-with-store":        StrategyStore,
+	"/visit-with-shards":       StrategyShards,
+	"/visit-with-singleflight": StrategySingleflight,
+}
+
+// Recorder wraps the collectors published on /metrics. It is safe for
+// concurrent use, since every method it exposes just delegates to
+// Prometheus client collectors, which are themselves goroutine-safe.
+type Recorder struct {
+	gatherer         prometheus.Gatherer
+	requestsTotal    *prometheus.CounterVec
+	requestsInFlight prometheus.Gauge
+	requestDuration  *prometheus.HistogramVec
+	contentionWait   *prometheus.HistogramVec
+}
+
+// NewRecorder builds a Recorder and registers its collectors against reg.
+// Handler serves exactly this registry's collectors, so reg must also be
+// the registry passed to Handler's caller (newMux threads the same *reg
+// through both).
+func NewRecorder(reg *prometheus.Registry) *Recorder {
+	r := &Recorder{
+		gatherer: reg,
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "synchro_visit_requests_total",
+			Help: "Total number of visit requests, partitioned by strategy.",
+		}, []string{"strategy"}),
+		requestsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "synchro_visit_requests_in_flight",
+			Help: "Number of visit requests currently being served.",
+		}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "synchro_visit_request_duration_seconds",
+			Help:    "Total time to serve a visit request, partitioned by strategy.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"strategy"}),
+		contentionWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "synchro_visit_contention_seconds",
+			Help:    "Time spent in the critical section that updates the counter, partitioned by strategy.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"strategy"}),
+	}
+
+	reg.MustRegister(r.requestsTotal, r.requestsInFlight, r.requestDuration, r.contentionWait)
+
+	return r
+}
+
+// ObserveContention records how long a handler spent in the critical
+// section that actually updates the shared counter, as opposed to the
+// total request latency recorded by Middleware.
+func (r *Recorder) ObserveContention(strategy string, d time.Duration) {
+	r.contentionWait.WithLabelValues(strategy).Observe(d.Seconds())
+}
+
+// Middleware records per-route request totals and latency histograms. It
+// mirrors middleware.Logger in that it reads the matched route pattern
+// after calling next, since chi only finishes populating the route
+// context once the handler chain has run.
+func (r *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.requestsInFlight.Inc()
+		defer r.requestsInFlight.Dec()
+
+		start := time.Now()
+		next.ServeHTTP(w, req)
+		elapsed := time.Since(start)
+
+		pattern := chi.RouteContext(req.Context()).RoutePattern()
+		strategy, ok := routeStrategy[pattern]
+		if !ok {
+			return
+		}
+
+		r.requestsTotal.WithLabelValues(strategy).Inc()
+		r.requestDuration.WithLabelValues(strategy).Observe(elapsed.Seconds())
+	})
+}
+
+// Handler returns the HTTP handler to mount on /metrics. It serves the
+// same registry r was built on, instead of promhttp.Handler's global
+// DefaultGatherer, so it reports real data even when newMux is built
+// against a private registry (as tests do).
+func (r *Recorder) Handler() http.Handler {
+	return promhttp.HandlerFor(r.gatherer, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestMetricsEndpointServesRecorderCollectors exercises /metrics end to end
+// through newMux, so a regression that points Handler at the wrong
+// registry (e.g. the global DefaultGatherer instead of the one the
+// Recorder was actually built on) shows up as a failing scrape instead of
+// silently shipping unobserved metrics.
+func TestMetricsEndpointServesRecorderCollectors(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "visits.wal")
+
+	mux, cleanup, err := newMux(storePath, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("erro ao construir mux: %v", err)
+	}
+	defer cleanup()
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	visitResp, err := http.Get(ts.URL + "/visit-with-mutex")
+	if err != nil {
+		t.Fatalf("erro ao visitar /visit-with-mutex: %v", err)
+	}
+	visitResp.Body.Close()
+
+	metricsResp, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("erro ao buscar /metrics: %v", err)
+	}
+	defer metricsResp.Body.Close()
+
+	body, err := io.ReadAll(metricsResp.Body)
+	if err != nil {
+		t.Fatalf("erro ao ler resposta de /metrics: %v", err)
+	}
+
+	want := `synchro_visit_requests_total{strategy="mutex"} 1`
+	if !strings.Contains(string(body), want) {
+		t.Errorf("esperado /metrics conter %q, corpo obtido:\n%s", want, body)
+	}
+}
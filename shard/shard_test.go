@@ -0,0 +1,27 @@
+package shard
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCounterIncAndGet(t *testing.T) {
+	c := NewCounter()
+
+	var wg sync.WaitGroup
+	numGoroutines := 1000
+
+	for i := 0; i < numGoroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.IncAndGet()
+		}()
+	}
+
+	wg.Wait()
+
+	if got := c.Read(); got != uint64(numGoroutines) {
+		t.Errorf("esperado %d, obtido %d", numGoroutines, got)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestGracefulShutdownDrainsInFlightRequests fires a burst of concurrent
+// requests against the channel-based handler, triggers shutdown while
+// they're still being served, and checks both that they drain
+// successfully instead of being cut off and that doing so doesn't leak
+// goroutines (notably the Counter's actor goroutine, which newMux's
+// cleanup func must stop).
+func TestGracefulShutdownDrainsInFlightRequests(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "visits.wal")
+
+	mux, cleanup, err := newMux(storePath, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("erro ao construir mux: %v", err)
+	}
+
+	// Hold each request open for a moment so Shutdown is guaranteed to be
+	// called while requests are still in flight, instead of racing to
+	// start it before they've all finished on their own.
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		mux.ServeHTTP(w, r)
+	})
+
+	ts := httptest.NewServer(slow)
+	defer ts.Close()
+
+	before := runtime.NumGoroutine()
+
+	var wg sync.WaitGroup
+	var succeeded int64
+	numRequests := 50
+
+	for i := 0; i < numRequests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp, err := http.Get(ts.URL + "/visit-with-channel")
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+			io.Copy(io.Discard, resp.Body)
+			if resp.StatusCode == http.StatusOK {
+				atomic.AddInt64(&succeeded, 1)
+			}
+		}()
+	}
+
+	// Give the requests a moment to actually reach the server before
+	// shutting it down, so Shutdown observes them as in-flight rather
+	// than racing the dial.
+	time.Sleep(20 * time.Millisecond)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := ts.Config.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("erro ao desligar servidor: %v", err)
+	}
+
+	wg.Wait()
+
+	if succeeded != int64(numRequests) {
+		t.Errorf("esperado %d requisições drenadas com sucesso, obtido %d", numRequests, succeeded)
+	}
+
+	cleanup()
+
+	// Goroutines spawned by net/http and the test transport wind down
+	// asynchronously, so poll instead of comparing counts immediately.
+	var after int
+	for i := 0; i < 50; i++ {
+		after = runtime.NumGoroutine()
+		if after <= before {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if after > before {
+		t.Errorf("possível vazamento de goroutines: antes=%d depois=%d", before, after)
+	}
+}
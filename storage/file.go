@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// FileStore persists the counter to a write-ahead log on disk so its
+// value survives process restarts. Every increment appends the new value
+// as a line and fsyncs before returning, trading throughput for
+// durability.
+type FileStore struct {
+	mu    sync.Mutex
+	file  *os.File
+	value uint64
+}
+
+// NewFileStore opens (creating if necessary) the WAL file at path and
+// replays it to recover the last known counter value.
+func NewFileStore(path string) (*FileStore, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("storage: open wal: %w", err)
+	}
+
+	value, err := recoverValue(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &FileStore{file: f, value: value}, nil
+}
+
+// recoverValue replays every entry in the WAL and returns the last one
+// written, leaving the file positioned at the end for further appends.
+func recoverValue(f *os.File) (uint64, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("storage: seek wal: %w", err)
+	}
+
+	var last uint64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		v, err := strconv.ParseUint(line, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("storage: parse wal entry %q: %w", line, err)
+		}
+		last = v
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("storage: read wal: %w", err)
+	}
+
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return 0, fmt.Errorf("storage: seek wal: %w", err)
+	}
+
+	return last, nil
+}
+
+func (s *FileStore) Load(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.value, nil
+}
+
+func (s *FileStore) Increment(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := s.value + 1
+
+	if _, err := fmt.Fprintf(s.file, "%d\n", next); err != nil {
+		return 0, fmt.Errorf("storage: append wal: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return 0, fmt.Errorf("storage: fsync wal: %w", err)
+	}
+
+	s.value = next
+
+	return s.value, nil
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// MemoryStore is a CounterStore backed by a plain in-process atomic
+// counter. It provides no durability and mainly exists as a baseline to
+// compare FileStore and RedisStore against.
+type MemoryStore struct {
+	value uint64
+}
+
+// NewMemoryStore returns a MemoryStore starting at zero.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Load(ctx context.Context) (uint64, error) {
+	return atomic.LoadUint64(&s.value), nil
+}
+
+func (s *MemoryStore) Increment(ctx context.Context) (uint64, error) {
+	return atomic.AddUint64(&s.value, 1), nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore persists the counter in Redis using INCR, so the value is
+// shared across every process pointed at the same key instead of living
+// in a single instance's memory.
+type RedisStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisStore returns a RedisStore that keeps its value under key on
+// client.
+func NewRedisStore(client *redis.Client, key string) *RedisStore {
+	return &RedisStore{client: client, key: key}
+}
+
+func (s *RedisStore) Load(ctx context.Context) (uint64, error) {
+	v, err := s.client.Get(ctx, s.key).Uint64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("storage: load from redis: %w", err)
+	}
+
+	return v, nil
+}
+
+func (s *RedisStore) Increment(ctx context.Context) (uint64, error) {
+	v, err := s.client.Incr(ctx, s.key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("storage: incr in redis: %w", err)
+	}
+
+	return uint64(v), nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
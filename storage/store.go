@@ -0,0 +1,22 @@
+// Package storage provides pluggable persistence backends for the visit
+// counter, so its value can survive a process restart instead of
+// resetting to zero every time.
+package storage
+
+import "context"
+
+// CounterStore persists a single monotonically increasing counter.
+// Implementations trade durability against throughput; see MemoryStore,
+// FileStore and RedisStore.
+type CounterStore interface {
+	// Load returns the counter's current value without modifying it,
+	// typically used to restore state at startup.
+	Load(ctx context.Context) (uint64, error)
+
+	// Increment atomically adds one to the counter and returns the new
+	// value.
+	Increment(ctx context.Context) (uint64, error)
+
+	// Close releases any resources held by the store.
+	Close() error
+}
@@ -1,12 +1,22 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"sync"
 	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/rafa-souza-dev/synchro/counter"
+	"github.com/rafa-souza-dev/synchro/hub"
+	"github.com/rafa-souza-dev/synchro/metrics"
+	"github.com/rafa-souza-dev/synchro/shard"
+	"github.com/rafa-souza-dev/synchro/storage"
 )
 
 func TestHealthCheck(t *testing.T) {
@@ -36,7 +46,7 @@ func TestHealthCheck(t *testing.T) {
 func TestVisitWithMutex(t *testing.T) {
 	var visits uint64
 	var mu sync.Mutex
-	handler := visitWithMutex(&visits, &mu)
+	handler := visitWithMutex(&visits, &mu, newTestRecorder(), newTestHub(t))
 
 	// Sequential test
 	for i := 1; i <= 5; i++ {
@@ -70,7 +80,7 @@ func TestVisitWithMutex(t *testing.T) {
 	// Concurrency test
 	var concurrentVisits uint64
 	var concurrentMu sync.Mutex
-	concurrentHandler := visitWithMutex(&concurrentVisits, &concurrentMu)
+	concurrentHandler := visitWithMutex(&concurrentVisits, &concurrentMu, newTestRecorder(), newTestHub(t))
 
 	var wg sync.WaitGroup
 	numGoroutines := 100
@@ -94,7 +104,7 @@ func TestVisitWithMutex(t *testing.T) {
 
 func TestVisitWithAtomic(t *testing.T) {
 	var visits uint64
-	handler := visitWithAtomic(&visits)
+	handler := visitWithAtomic(&visits, newTestRecorder(), newTestHub(t))
 
 	// Sequential test
 	for i := 1; i <= 5; i++ {
@@ -127,7 +137,7 @@ func TestVisitWithAtomic(t *testing.T) {
 
 	// Concurrency test
 	var concurrentVisits uint64
-	concurrentHandler := visitWithAtomic(&concurrentVisits)
+	concurrentHandler := visitWithAtomic(&concurrentVisits, newTestRecorder(), newTestHub(t))
 
 	var wg sync.WaitGroup
 	numGoroutines := 100
@@ -150,12 +160,10 @@ func TestVisitWithAtomic(t *testing.T) {
 }
 
 func TestVisitWithChannel(t *testing.T) {
-	var visits uint64
-	ch := make(chan *uint64, 1)
-	ch <- &visits
-	defer close(ch)
+	ctr := counter.NewCounter()
+	defer ctr.Close()
 
-	handler := visitWithChannel(ch)
+	handler := visitWithChannel(ctr, newTestRecorder(), newTestHub(t))
 
 	// Sequential test
 	for i := 1; i <= 5; i++ {
@@ -181,18 +189,16 @@ func TestVisitWithChannel(t *testing.T) {
 			t.Errorf("resposta não contém mensagem esperada: %s", string(body))
 		}
 
-		if visits != uint64(i) {
-			t.Errorf("esperado %d visitas, obtido %d", i, visits)
+		if visits, err := ctr.Get(req.Context()); err != nil || visits != uint64(i) {
+			t.Errorf("esperado %d visitas, obtido %d (err=%v)", i, visits, err)
 		}
 	}
 
 	// Concurrency test
-	var concurrentVisits uint64
-	concurrentCh := make(chan *uint64, 1)
-	concurrentCh <- &concurrentVisits
-	defer close(concurrentCh)
+	concurrentCtr := counter.NewCounter()
+	defer concurrentCtr.Close()
 
-	concurrentHandler := visitWithChannel(concurrentCh)
+	concurrentHandler := visitWithChannel(concurrentCtr, newTestRecorder(), newTestHub(t))
 
 	var wg sync.WaitGroup
 	numGoroutines := 100
@@ -209,15 +215,15 @@ func TestVisitWithChannel(t *testing.T) {
 
 	wg.Wait()
 
-	if concurrentVisits != uint64(numGoroutines) {
-		t.Errorf("esperado %d visitas concorrentes, obtido %d", numGoroutines, concurrentVisits)
+	if concurrentVisits, err := concurrentCtr.Get(context.Background()); err != nil || concurrentVisits != uint64(numGoroutines) {
+		t.Errorf("esperado %d visitas concorrentes, obtido %d (err=%v)", numGoroutines, concurrentVisits, err)
 	}
 }
 
 func TestVisitWithMutexRaceCondition(t *testing.T) {
 	var visits uint64
 	var mu sync.Mutex
-	handler := visitWithMutex(&visits, &mu)
+	handler := visitWithMutex(&visits, &mu, newTestRecorder(), newTestHub(t))
 
 	var wg sync.WaitGroup
 	numGoroutines := 1000
@@ -242,7 +248,7 @@ func TestVisitWithMutexRaceCondition(t *testing.T) {
 
 func TestVisitWithAtomicRaceCondition(t *testing.T) {
 	var visits uint64
-	handler := visitWithAtomic(&visits)
+	handler := visitWithAtomic(&visits, newTestRecorder(), newTestHub(t))
 
 	var wg sync.WaitGroup
 	numGoroutines := 1000
@@ -266,12 +272,10 @@ func TestVisitWithAtomicRaceCondition(t *testing.T) {
 }
 
 func TestVisitWithChannelRaceCondition(t *testing.T) {
-	var visits uint64
-	ch := make(chan *uint64, 1)
-	ch <- &visits
-	defer close(ch)
+	ctr := counter.NewCounter()
+	defer ctr.Close()
 
-	handler := visitWithChannel(ch)
+	handler := visitWithChannel(ctr, newTestRecorder(), newTestHub(t))
 
 	var wg sync.WaitGroup
 	numGoroutines := 1000
@@ -289,15 +293,15 @@ func TestVisitWithChannelRaceCondition(t *testing.T) {
 
 	wg.Wait()
 
-	if visits != expectedVisits {
-		t.Errorf("race condition detectada: esperado %d visitas, obtido %d", expectedVisits, visits)
+	if visits, err := ctr.Get(context.Background()); err != nil || visits != expectedVisits {
+		t.Errorf("race condition detectada: esperado %d visitas, obtido %d (err=%v)", expectedVisits, visits, err)
 	}
 }
 
 func BenchmarkVisitWithMutex(b *testing.B) {
 	var visits uint64
 	var mu sync.Mutex
-	handler := visitWithMutex(&visits, &mu)
+	handler := visitWithMutex(&visits, &mu, newTestRecorder(), newTestHub(b))
 
 	req := httptest.NewRequest(http.MethodGet, "/visit-with-mutex", nil)
 
@@ -310,7 +314,7 @@ func BenchmarkVisitWithMutex(b *testing.B) {
 
 func BenchmarkVisitWithAtomic(b *testing.B) {
 	var visits uint64
-	handler := visitWithAtomic(&visits)
+	handler := visitWithAtomic(&visits, newTestRecorder(), newTestHub(b))
 
 	req := httptest.NewRequest(http.MethodGet, "/visit-with-atomic", nil)
 
@@ -322,12 +326,10 @@ func BenchmarkVisitWithAtomic(b *testing.B) {
 }
 
 func BenchmarkVisitWithChannel(b *testing.B) {
-	var visits uint64
-	ch := make(chan *uint64, 1)
-	ch <- &visits
-	defer close(ch)
+	ctr := counter.NewCounter()
+	defer ctr.Close()
 
-	handler := visitWithChannel(ch)
+	handler := visitWithChannel(ctr, newTestRecorder(), newTestHub(b))
 
 	req := httptest.NewRequest(http.MethodGet, "/visit-with-channel", nil)
 
@@ -337,3 +339,102 @@ func BenchmarkVisitWithChannel(b *testing.B) {
 		handler(w, req)
 	}
 }
+
+// newTestRecorder builds a metrics.Recorder against a private registry so
+// tests can create as many as they like without tripping Prometheus's
+// duplicate-collector panic on the default registerer.
+func newTestRecorder() *metrics.Recorder {
+	return metrics.NewRecorder(prometheus.NewRegistry())
+}
+
+func TestVisitWithChannelReturnsErrorWhenCounterClosed(t *testing.T) {
+	ctr := counter.NewCounter()
+	ctr.Close()
+
+	handler := visitWithChannel(ctr, newTestRecorder(), newTestHub(t))
+
+	req := httptest.NewRequest(http.MethodGet, "/visit-with-channel", nil)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusInternalServerError {
+		t.Errorf("esperado status %d, obtido %d", http.StatusInternalServerError, res.StatusCode)
+	}
+}
+
+// runConcurrencyBenchmarks runs newHandler against path at increasing
+// levels of parallelism, so the mutex/atomic/channel/shards/singleflight
+// strategies can be compared on equal footing as contention grows.
+func runConcurrencyBenchmarks(b *testing.B, path string, newHandler func() http.HandlerFunc) {
+	for _, p := range []int{1, 8, 64, 512} {
+		b.Run(fmt.Sprintf("p=%d", p), func(b *testing.B) {
+			handler := newHandler()
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+
+			b.SetParallelism(p)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					w := httptest.NewRecorder()
+					handler(w, req)
+				}
+			})
+		})
+	}
+}
+
+// The concurrency benchmarks below compare raw synchronization strategies
+// against one another, so none of them publish to a hub: a real Hub fans
+// every call through a single run-loop goroutine, and that serialization
+// would dwarf the difference between a mutex, an atomic and a channel
+// actor, confounding the very comparison these benchmarks exist to make.
+// visitWithShards and visitWithSingleflight never took a hub, so passing
+// nil here puts all five on equal footing.
+
+func BenchmarkVisitWithMutexConcurrency(b *testing.B) {
+	runConcurrencyBenchmarks(b, "/visit-with-mutex", func() http.HandlerFunc {
+		var visits uint64
+		var mu sync.Mutex
+		return visitWithMutex(&visits, &mu, newTestRecorder(), nil)
+	})
+}
+
+func BenchmarkVisitWithAtomicConcurrency(b *testing.B) {
+	runConcurrencyBenchmarks(b, "/visit-with-atomic", func() http.HandlerFunc {
+		var visits uint64
+		return visitWithAtomic(&visits, newTestRecorder(), nil)
+	})
+}
+
+func BenchmarkVisitWithChannelConcurrency(b *testing.B) {
+	runConcurrencyBenchmarks(b, "/visit-with-channel", func() http.HandlerFunc {
+		return visitWithChannel(counter.NewCounter(), newTestRecorder(), nil)
+	})
+}
+
+func BenchmarkVisitWithShardsConcurrency(b *testing.B) {
+	runConcurrencyBenchmarks(b, "/visit-with-shards", func() http.HandlerFunc {
+		return visitWithShards(shard.NewCounter(), newTestRecorder())
+	})
+}
+
+func BenchmarkVisitWithSingleflightConcurrency(b *testing.B) {
+	runConcurrencyBenchmarks(b, "/visit-with-singleflight", func() http.HandlerFunc {
+		return visitWithSingleflight(storage.NewMemoryStore(), newTestRecorder())
+	})
+}
+
+// newTestHub builds a hub.Hub and registers its Close with tb's cleanup,
+// so tests and benchmarks don't need to remember to stop it themselves.
+func newTestHub(tb testing.TB) *hub.Hub {
+	tb.Helper()
+
+	h := hub.New()
+	tb.Cleanup(func() { h.Close() })
+
+	return h
+}